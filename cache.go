@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// cacheEntry is a single cached upstream response.
+type cacheEntry struct {
+	body      []byte
+	expiresAt time.Time
+}
+
+// responseCache is a small in-process cache of upstream GBFS feed bodies,
+// keyed by absolute URL. Entries are considered fresh until
+// last_updated + ttl, as reported by the feed itself, capped at maxTTL if
+// maxTTL is non-zero. This keeps a Prometheus scraping every few seconds
+// from hammering upstream city APIs that only refresh once a minute or so.
+//
+// entries is never pruned: expired entries are just skipped by get() and
+// overwritten by the next set(), not deleted. That's fine in practice since
+// the key space is bounded by the feed URLs listed in -config.file, which
+// is static for the life of the process, but it does mean this isn't an
+// LRU or anything with its own eviction policy.
+type responseCache struct {
+	mu      sync.Mutex
+	maxTTL  time.Duration
+	entries map[string]cacheEntry
+}
+
+// newResponseCache builds an empty cache. A maxTTL of 0 means no cap is
+// applied beyond whatever the feed itself reports.
+func newResponseCache(maxTTL time.Duration) *responseCache {
+	return &responseCache{maxTTL: maxTTL, entries: make(map[string]cacheEntry)}
+}
+
+// get returns the cached body for url, if present and not yet expired.
+func (c *responseCache) get(url string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[url]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.body, true
+}
+
+// set stores body for url, expiring it at lastUpdated+ttl (both as reported
+// by the feed), capped at maxTTL if configured.
+func (c *responseCache) set(url string, body []byte, lastUpdated, ttl int64) {
+	ttlDuration := time.Duration(ttl) * time.Second
+	if c.maxTTL > 0 && ttlDuration > c.maxTTL {
+		ttlDuration = c.maxTTL
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[url] = cacheEntry{
+		body:      body,
+		expiresAt: time.Unix(lastUpdated, 0).Add(ttlDuration),
+	}
+}
+
+// fetchCachedURL fetches url, serving from cache when possible, and records
+// cache hit/miss and upstream request duration metrics labeled by target
+// (the discovery document URL the caller originally probed).
+func fetchCachedURL(ctx context.Context, cache *responseCache, metrics *gbfsMetrics, target, url string) ([]byte, error) {
+	if body, ok := cache.get(url); ok {
+		metrics.cacheHitsCounter.WithLabelValues(target).Inc()
+		return body, nil
+	}
+	metrics.cacheMissesCounter.WithLabelValues(target).Inc()
+
+	start := time.Now()
+	body, err := fetchURL(ctx, target, url)
+	metrics.upstreamRequestDuration.WithLabelValues(target).Observe(time.Since(start).Seconds())
+	if err != nil {
+		return nil, err
+	}
+
+	var freshness GBFSAPIResponse
+	if err := json.Unmarshal(body, &freshness); err == nil {
+		cache.set(url, body, freshness.LastUpdated, freshness.TTL)
+	}
+	return body, nil
+}
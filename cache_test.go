@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResponseCacheExpiry(t *testing.T) {
+	now := time.Now()
+	lastUpdated := now.Unix()
+
+	tests := []struct {
+		name      string
+		maxTTL    time.Duration
+		ttl       int64
+		checkAt   time.Time
+		wantFresh bool
+	}{
+		{
+			name:      "within ttl",
+			ttl:       60,
+			checkAt:   now.Add(30 * time.Second),
+			wantFresh: true,
+		},
+		{
+			name:      "past ttl",
+			ttl:       60,
+			checkAt:   now.Add(90 * time.Second),
+			wantFresh: false,
+		},
+		{
+			name:      "maxTTL caps a longer feed ttl",
+			maxTTL:    10 * time.Second,
+			ttl:       60,
+			checkAt:   now.Add(30 * time.Second),
+			wantFresh: false,
+		},
+		{
+			name:      "maxTTL does not extend a shorter feed ttl",
+			maxTTL:    60 * time.Second,
+			ttl:       10,
+			checkAt:   now.Add(30 * time.Second),
+			wantFresh: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newResponseCache(tt.maxTTL)
+			c.set("url", []byte("body"), lastUpdated, tt.ttl)
+
+			entry := c.entries["url"]
+			fresh := tt.checkAt.Before(entry.expiresAt)
+			if fresh != tt.wantFresh {
+				t.Fatalf("fresh at %v = %v, want %v (expiresAt=%v)", tt.checkAt, fresh, tt.wantFresh, entry.expiresAt)
+			}
+		})
+	}
+}
+
+func TestResponseCacheGetExpired(t *testing.T) {
+	c := newResponseCache(0)
+	c.set("url", []byte("stale"), time.Now().Add(-time.Hour).Unix(), 60)
+
+	if _, ok := c.get("url"); ok {
+		t.Fatalf("get() returned an entry whose ttl has long since passed")
+	}
+}
+
+func TestResponseCacheGetFresh(t *testing.T) {
+	c := newResponseCache(0)
+	c.set("url", []byte("fresh"), time.Now().Unix(), 60)
+
+	body, ok := c.get("url")
+	if !ok || string(body) != "fresh" {
+		t.Fatalf("get() = (%q, %v), want (\"fresh\", true)", body, ok)
+	}
+}
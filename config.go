@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultScrapeTimeout is used when a system doesn't set scrape_timeout.
+const defaultScrapeTimeout = 10 * time.Second
+
+// SystemConfig describes a single GBFS system to probe, in the style of a
+// blackbox_exporter module.
+type SystemConfig struct {
+	Name          string            `yaml:"name"`
+	DiscoveryURL  string            `yaml:"discovery_url"`
+	Language      string            `yaml:"language,omitempty"`
+	ScrapeTimeout time.Duration     `yaml:"scrape_timeout,omitempty"`
+	Feeds         []string          `yaml:"feeds,omitempty"`
+	Labels        map[string]string `yaml:"labels,omitempty"`
+}
+
+// wantsFeed reports whether feed should be fetched for this system. An
+// empty Feeds list means "fetch every known feed the discovery document
+// offers".
+func (s *SystemConfig) wantsFeed(feed string) bool {
+	if len(s.Feeds) == 0 {
+		return true
+	}
+	for _, wanted := range s.Feeds {
+		if wanted == feed {
+			return true
+		}
+	}
+	return false
+}
+
+// timeout returns the system's configured scrape timeout, or
+// defaultScrapeTimeout if unset.
+func (s *SystemConfig) timeout() time.Duration {
+	if s.ScrapeTimeout <= 0 {
+		return defaultScrapeTimeout
+	}
+	return s.ScrapeTimeout
+}
+
+// Config is the top-level shape of the -config.file YAML document.
+type Config struct {
+	Systems []SystemConfig `yaml:"systems"`
+}
+
+// validate rejects a config whose static labels would collide with a
+// variable label gbfs_exporter already attaches to the same metric vectors,
+// which would otherwise panic prometheus.NewGaugeVec on the next probe.
+func (c *Config) validate() error {
+	for _, system := range c.Systems {
+		for label := range system.Labels {
+			if reservedMetricLabels[label] {
+				return fmt.Errorf("system %q: label %q is reserved for a metric's variable label and cannot be set as a static label", system.Name, label)
+			}
+		}
+	}
+	return nil
+}
+
+// LoadConfig reads and parses the config file at path.
+func LoadConfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := new(Config)
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Lookup returns the system config registered under name.
+func (c *Config) Lookup(name string) (*SystemConfig, error) {
+	for i := range c.Systems {
+		if c.Systems[i].Name == name {
+			return &c.Systems[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no system named %q in config", name)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfigRejectsReservedLabels(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr bool
+	}{
+		{
+			name: "label collides with a variable label",
+			yaml: `
+systems:
+  - name: testsys
+    discovery_url: http://example.com/gbfs.json
+    labels:
+      station_id: oops
+`,
+			wantErr: true,
+		},
+		{
+			name: "label does not collide",
+			yaml: `
+systems:
+  - name: testsys
+    discovery_url: http://example.com/gbfs.json
+    labels:
+      city: testville
+`,
+		},
+		{
+			name: "no labels at all",
+			yaml: `
+systems:
+  - name: testsys
+    discovery_url: http://example.com/gbfs.json
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yml")
+			if err := ioutil.WriteFile(path, []byte(tt.yaml), 0o644); err != nil {
+				t.Fatalf("writing fixture config: %v", err)
+			}
+
+			_, err := LoadConfig(path)
+			if tt.wantErr && err == nil {
+				t.Fatalf("LoadConfig() error = nil, want error")
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("LoadConfig() error = %v, want nil", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// FreeBikeStatus holds the status of a single dockless/floating vehicle.
+type FreeBikeStatus struct {
+	BikeID        string `json:"bike_id"`
+	VehicleTypeID string `json:"vehicle_type_id,omitempty"`
+	IsReserved    bool   `json:"is_reserved"`
+	IsDisabled    bool   `json:"is_disabled"`
+	// CurrentRangeMeters is a pointer since 0 is a real, meaningful reading
+	// (a dead-battery vehicle) that must be distinguished from the field
+	// being absent altogether.
+	CurrentRangeMeters *float64 `json:"current_range_meters,omitempty"`
+}
+
+// UnmarshalJSON handles is_reserved/is_disabled being booleans in GBFS v3
+// but 0/1 integers in GBFS v2.
+func (s *FreeBikeStatus) UnmarshalJSON(data []byte) error {
+	type Alias FreeBikeStatus
+	alias := &struct {
+		IsReserved intOrBool `json:"is_reserved"`
+		IsDisabled intOrBool `json:"is_disabled"`
+
+		*Alias
+	}{
+		Alias: (*Alias)(s),
+	}
+
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return err
+	}
+	s.IsReserved = bool(alias.IsReserved)
+	s.IsDisabled = bool(alias.IsDisabled)
+	return nil
+}
+
+// FreeBikeStatusAPIResponse holds the API response for free bike status.
+type FreeBikeStatusAPIResponse struct {
+	Data struct {
+		Bikes []FreeBikeStatus `json:"bikes"`
+	} `json:"data"`
+	GBFSAPIResponse
+}
+
+// GetFreeBikeStatuses parses a free_bike_status feed.
+func GetFreeBikeStatuses(body []byte) (*FreeBikeStatusAPIResponse, error) {
+	resp := new(FreeBikeStatusAPIResponse)
+	err := json.Unmarshal(body, &resp)
+	return resp, err
+}
+
+// VehicleType holds the static metadata GBFS publishes about a vehicle
+// type, such as a scooter or e-bike model.
+type VehicleType struct {
+	ID             string  `json:"vehicle_type_id"`
+	FormFactor     string  `json:"form_factor"`
+	PropulsionType string  `json:"propulsion_type"`
+	MaxRangeMeters float64 `json:"max_range_meters,omitempty"`
+}
+
+// VehicleTypesAPIResponse holds the API response for vehicle types.
+type VehicleTypesAPIResponse struct {
+	Data struct {
+		VehicleTypes []VehicleType `json:"vehicle_types"`
+	} `json:"data"`
+	GBFSAPIResponse
+}
+
+// GetVehicleTypes parses a vehicle_types feed.
+func GetVehicleTypes(body []byte) (*VehicleTypesAPIResponse, error) {
+	resp := new(VehicleTypesAPIResponse)
+	err := json.Unmarshal(body, &resp)
+	return resp, err
+}
+
+// recordFreeBikeStatus populates the free_bikes_total gauge and
+// free_bike_range_meters histogram from a free_bike_status feed.
+func (m *gbfsMetrics) recordFreeBikeStatus(body []byte) error {
+	freeBikeResp, err := GetFreeBikeStatuses(body)
+	if err != nil {
+		return err
+	}
+
+	type freeBikeGroup struct {
+		vehicleTypeID string
+		isReserved    string
+		isDisabled    string
+	}
+	counts := make(map[freeBikeGroup]int64)
+	for _, bike := range freeBikeResp.Data.Bikes {
+		group := freeBikeGroup{
+			vehicleTypeID: bike.VehicleTypeID,
+			isReserved:    strconv.FormatBool(bike.IsReserved),
+			isDisabled:    strconv.FormatBool(bike.IsDisabled),
+		}
+		counts[group]++
+
+		if bike.CurrentRangeMeters != nil {
+			m.freeBikeRangeHistogram.With(prometheus.Labels{"vehicle_type_id": bike.VehicleTypeID}).
+				Observe(*bike.CurrentRangeMeters)
+		}
+	}
+
+	for group, count := range counts {
+		labels := prometheus.Labels{
+			"vehicle_type_id": group.vehicleTypeID,
+			"is_reserved":     group.isReserved,
+			"is_disabled":     group.isDisabled,
+		}
+		m.freeBikesGauge.With(labels).Set(float64(count))
+	}
+	return nil
+}
+
+// recordVehicleTypes populates the vehicle_type_info info gauge from a
+// vehicle_types feed.
+func (m *gbfsMetrics) recordVehicleTypes(body []byte) error {
+	vehicleTypesResp, err := GetVehicleTypes(body)
+	if err != nil {
+		return err
+	}
+
+	for _, vehicleType := range vehicleTypesResp.Data.VehicleTypes {
+		labels := prometheus.Labels{
+			"vehicle_type_id":  vehicleType.ID,
+			"form_factor":      vehicleType.FormFactor,
+			"propulsion_type":  vehicleType.PropulsionType,
+			"max_range_meters": strconv.FormatFloat(vehicleType.MaxRangeMeters, 'f', -1, 64),
+		}
+		m.vehicleTypeInfoGauge.With(labels).Set(1)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestFreeBikeStatusUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want FreeBikeStatus
+	}{
+		{
+			name: "GBFS v3 booleans",
+			body: `{"bike_id":"1","is_reserved":true,"is_disabled":false}`,
+			want: FreeBikeStatus{BikeID: "1", IsReserved: true, IsDisabled: false},
+		},
+		{
+			name: "GBFS v2 0/1 integers",
+			body: `{"bike_id":"1","is_reserved":0,"is_disabled":1}`,
+			want: FreeBikeStatus{BikeID: "1", IsReserved: false, IsDisabled: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got FreeBikeStatus
+			if err := got.UnmarshalJSON([]byte(tt.body)); err != nil {
+				t.Fatalf("UnmarshalJSON() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("UnmarshalJSON() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
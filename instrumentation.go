@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"runtime"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// version and revision are overridden at build time via -ldflags, in the
+// style of most Prometheus exporters.
+var (
+	version  = "unknown"
+	revision = "unknown"
+)
+
+// Stages reported on scrape_errors_total, one per place probeSystem can
+// fail.
+const (
+	stageFetch     = "fetch"
+	stageRead      = "read"
+	stageUnmarshal = "unmarshal"
+	stageSchema    = "schema"
+)
+
+// stageError tags an error with the probeSystem stage it occurred in, so
+// scrape_errors_total can be incremented with the right label.
+type stageError struct {
+	stage string
+	err   error
+}
+
+func (e *stageError) Error() string { return e.err.Error() }
+func (e *stageError) Unwrap() error { return e.err }
+
+// stageOf returns the stage an error occurred in, falling back to
+// stageFetch for errors that didn't originate from fetchURL.
+func stageOf(err error) string {
+	var se *stageError
+	if errors.As(err, &se) {
+		return se.stage
+	}
+	return stageFetch
+}
+
+// These describe the exporter's own health, as opposed to the health of the
+// GBFS feeds it probes, so they're registered on the default registerer and
+// served from /metrics rather than /probe.
+var (
+	httpClientRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "exporter",
+		Name:      "http_client_requests_total",
+		Help:      "Outbound HTTP requests this exporter has made to upstream GBFS feeds",
+	}, []string{"code", "method", "target"})
+
+	httpClientRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "exporter",
+		Name:      "http_client_request_duration_seconds",
+		Help:      "Duration of outbound HTTP requests this exporter has made to upstream GBFS feeds",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"code", "method", "target"})
+
+	httpClientTraceDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "exporter",
+		Name:      "http_client_trace_duration_seconds",
+		Help:      "Time since the start of an outbound HTTP request at which a connection lifecycle event fired",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"event"})
+
+	scrapeErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "exporter",
+		Name:      "scrape_errors_total",
+		Help:      "Number of errors encountered while probing GBFS systems, by stage",
+	}, []string{"stage"})
+
+	buildInfoGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "exporter",
+		Name:      "build_info",
+		Help:      "A metric with a constant 1 value, labeled with exporter build information",
+	}, []string{"version", "revision", "goversion"})
+)
+
+func init() {
+	prometheus.MustRegister(
+		httpClientRequestsTotal,
+		httpClientRequestDuration,
+		httpClientTraceDuration,
+		scrapeErrorsTotal,
+		buildInfoGauge,
+	)
+	buildInfoGauge.WithLabelValues(version, revision, runtime.Version()).Set(1)
+}
+
+// httpClientTrace reports connection lifecycle events for every outbound
+// request to httpClientTraceDuration.
+var httpClientTrace = &promhttp.InstrumentTrace{
+	DNSStart:          func(t float64) { httpClientTraceDuration.WithLabelValues("dns_start").Observe(t) },
+	DNSDone:           func(t float64) { httpClientTraceDuration.WithLabelValues("dns_done").Observe(t) },
+	ConnectStart:      func(t float64) { httpClientTraceDuration.WithLabelValues("connect_start").Observe(t) },
+	ConnectDone:       func(t float64) { httpClientTraceDuration.WithLabelValues("connect_done").Observe(t) },
+	TLSHandshakeStart: func(t float64) { httpClientTraceDuration.WithLabelValues("tls_handshake_start").Observe(t) },
+	TLSHandshakeDone:  func(t float64) { httpClientTraceDuration.WithLabelValues("tls_handshake_done").Observe(t) },
+}
+
+// instrumentedTransport builds a RoundTripper that reports every request it
+// makes against httpClientRequestsTotal/httpClientRequestDuration/
+// httpClientTraceDuration, labeled with target (the system being probed).
+func instrumentedTransport(target string) http.RoundTripper {
+	counter := httpClientRequestsTotal.MustCurryWith(prometheus.Labels{"target": target})
+	duration := httpClientRequestDuration.MustCurryWith(prometheus.Labels{"target": target})
+
+	base := promhttp.InstrumentRoundTripperTrace(httpClientTrace, http.DefaultTransport)
+	base = promhttp.InstrumentRoundTripperCounter(counter, base)
+	base = promhttp.InstrumentRoundTripperDuration(duration, base)
+	return base
+}
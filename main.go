@@ -1,11 +1,14 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
@@ -18,6 +21,41 @@ const (
 	listenAddress string = ":9607"
 )
 
+// Feed names as they appear in a GBFS auto-discovery document.
+const (
+	feedStationStatus      string = "station_status"
+	feedStationInformation string = "station_information"
+	feedFreeBikeStatus     string = "free_bike_status"
+	feedVehicleTypes       string = "vehicle_types"
+	feedSystemInformation  string = "system_information"
+	feedSystemAlerts       string = "system_alerts"
+)
+
+// knownFeeds lists the feeds this exporter knows how to do something with.
+// Anything else present in a discovery document is ignored.
+var knownFeeds = []string{
+	feedStationStatus,
+	feedStationInformation,
+	feedFreeBikeStatus,
+	feedVehicleTypes,
+	feedSystemInformation,
+	feedSystemAlerts,
+}
+
+var cacheMaxTTL = flag.Duration("cache.max-ttl", 0,
+	"Upper bound on how long an upstream feed response is cached, regardless of the ttl it reports. 0 means no cap.")
+
+var configFile = flag.String("config.file", "gbfs_exporter.yml",
+	"Path to a YAML config file listing the GBFS systems this exporter can probe.")
+
+// cache holds fetched upstream feed responses so that frequent scrapes
+// don't translate into frequent upstream requests.
+var cache *responseCache
+
+// config holds the loaded -config.file, looked up by module/target name on
+// every probe.
+var config *Config
+
 /***********
  BEGIN UTIL
 ***********/
@@ -38,6 +76,28 @@ func BoolToFloat64(x bool) float64 {
 	return 0
 }
 
+// fetchURL performs a GET against url, bound by ctx's deadline, and returns
+// the response body. target labels the request/duration/trace metrics
+// recorded for it, and is typically the system being probed rather than url
+// itself (which may be one of several feeds belonging to that system).
+func fetchURL(ctx context.Context, target, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, &stageError{stage: stageFetch, err: err}
+	}
+	client := &http.Client{Transport: instrumentedTransport(target)}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, &stageError{stage: stageFetch, err: err}
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &stageError{stage: stageRead, err: err}
+	}
+	return body, nil
+}
+
 /***********
  END UTIL
 ***********/
@@ -48,6 +108,67 @@ type GBFSAPIResponse struct {
 	TTL         int64 `json:"ttl"`
 }
 
+// GBFSFeed is a single entry in a GBFS auto-discovery document, pointing at
+// one of the other GBFS feed files (station_status.json, and so on).
+type GBFSFeed struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// gbfsFeedsBlock is the `{"feeds": [...]}` shape that shows up both at the
+// top of `data` in GBFS v3 and nested per-language in GBFS v2.
+type gbfsFeedsBlock struct {
+	Feeds []GBFSFeed `json:"feeds"`
+}
+
+// GBFSDiscoveryResponse holds the parsed contents of a GBFS auto-discovery
+// document (conventionally served as gbfs.json). GBFS v2 keys `data` by
+// language; GBFS v3 collapses that down to a single feed list. Data is kept
+// raw here so FeedsForLanguage can handle either shape.
+type GBFSDiscoveryResponse struct {
+	Data json.RawMessage `json:"data"`
+	GBFSAPIResponse
+}
+
+// GetDiscoveryDocument parses a GBFS auto-discovery document.
+func GetDiscoveryDocument(body []byte) (*GBFSDiscoveryResponse, error) {
+	resp := new(GBFSDiscoveryResponse)
+	err := json.Unmarshal(body, &resp)
+	return resp, err
+}
+
+// FeedsForLanguage returns the feed list for the given language. GBFS v3
+// documents have no language keys, so language is ignored for them. For
+// GBFS v2 documents, language selects which set of feeds to use; if empty,
+// "en" is preferred and otherwise the first language found is used.
+func (d *GBFSDiscoveryResponse) FeedsForLanguage(language string) ([]GBFSFeed, error) {
+	var v3 gbfsFeedsBlock
+	if err := json.Unmarshal(d.Data, &v3); err == nil && len(v3.Feeds) > 0 {
+		return v3.Feeds, nil
+	}
+
+	var byLanguage map[string]gbfsFeedsBlock
+	if err := json.Unmarshal(d.Data, &byLanguage); err != nil {
+		return nil, errors.Wrap(err, "discovery document matches neither the GBFS v2 nor v3 shape")
+	}
+
+	if language != "" {
+		block, ok := byLanguage[language]
+		if !ok {
+			return nil, fmt.Errorf("language %q not present in discovery document", language)
+		}
+		return block.Feeds, nil
+	}
+
+	if block, ok := byLanguage["en"]; ok {
+		return block.Feeds, nil
+	}
+	for _, block := range byLanguage {
+		return block.Feeds, nil
+	}
+	return nil, fmt.Errorf("discovery document contains no feeds")
+}
+
 // StationStatus holds the status of stations
 type StationStatus struct {
 	ID             string `json:"station_id"`
@@ -61,13 +182,32 @@ type StationStatus struct {
 	LastReported   int64  `json:"last_reported"`
 }
 
+// intOrBool unmarshals either a JSON boolean (GBFS v3) or a 0/1 integer
+// (GBFS v2) into a bool.
+type intOrBool bool
+
+func (b *intOrBool) UnmarshalJSON(data []byte) error {
+	var asBool bool
+	if err := json.Unmarshal(data, &asBool); err == nil {
+		*b = intOrBool(asBool)
+		return nil
+	}
+
+	var asInt int64
+	if err := json.Unmarshal(data, &asInt); err != nil {
+		return err
+	}
+	*b = asInt != 0
+	return nil
+}
+
 // UnmarshalJSON I hate warnings
 func (s *StationStatus) UnmarshalJSON(data []byte) error {
 	type Alias StationStatus
 	alias := &struct {
-		Installed int64 `json:"is_installed"`
-		Renting   int64 `json:"is_renting"`
-		Returning int64 `json:"is_returning"`
+		Installed intOrBool `json:"is_installed"`
+		Renting   intOrBool `json:"is_renting"`
+		Returning intOrBool `json:"is_returning"`
 
 		*Alias
 	}{
@@ -77,9 +217,9 @@ func (s *StationStatus) UnmarshalJSON(data []byte) error {
 	if err := json.Unmarshal(data, &alias); err != nil {
 		return err
 	}
-	s.Installed = alias.Installed != 0
-	s.Renting = alias.Renting != 0
-	s.Returning = alias.Returning != 0
+	s.Installed = bool(alias.Installed)
+	s.Renting = bool(alias.Renting)
+	s.Returning = bool(alias.Returning)
 	return nil
 }
 
@@ -98,99 +238,390 @@ func GetStationStatuses(body []byte) (*StationStatusAPIResponse, error) {
 	return resp, err
 }
 
-func probeGBFS(w http.ResponseWriter, r *http.Request) {
-	params := r.URL.Query()
-	target := params.Get("target")
-	if target == "" {
-		http.Error(w, "Target parameter missing", 400)
-		return
-	}
+// reservedMetricLabels lists every variable label name used across the
+// gbfsMetrics vectors. A system's static config labels are merged into
+// ConstLabels alongside these, so any collision would make
+// prometheus.NewGaugeVec panic on the next probe; config.go validates
+// against this set at load time instead.
+var reservedMetricLabels = map[string]bool{
+	"feed":             true,
+	"station_id":       true,
+	"name":             true,
+	"region_id":        true,
+	"lat":              true,
+	"lon":              true,
+	"vehicle_type_id":  true,
+	"is_reserved":      true,
+	"is_disabled":      true,
+	"form_factor":      true,
+	"propulsion_type":  true,
+	"max_range_meters": true,
+	"system_id":        true,
+	"timezone":         true,
+	"type":             true,
+	"target":           true,
+}
 
-	resp, err := http.Get(target)
-	if err != nil {
-		// Room for improvement, check types of errors that can be returned (ex. timeouts, redirects)
-		http.Error(w, fmt.Sprintf("HTTP error: %v", err), 400)
-		return
-	}
-	defer resp.Body.Close()
+// gbfsMetrics bundles the metric vectors shared across every feed produced
+// by a single probe, so that they can be passed around and registered as a
+// unit.
+type gbfsMetrics struct {
+	bikesAvailableGauge *prometheus.GaugeVec
+	bikesDisabledGauge  *prometheus.GaugeVec
+	docksAvailableGauge *prometheus.GaugeVec
+	docksDisabledGauge  *prometheus.GaugeVec
+	installedGauge      *prometheus.GaugeVec
+	rentingGauge        *prometheus.GaugeVec
+	lastReportedGauge   *prometheus.GaugeVec
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to read HTTP body of target '%s': %v", target, err), 500)
-		return
-	}
+	feedLastUpdatedGauge *prometheus.GaugeVec
+	feedTTLGauge         *prometheus.GaugeVec
 
-	var (
-		bikesAvailableGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace: namespace,
-			Name:      "bikes_available",
-			Help:      "The number of bikes available for rental",
-		}, []string{"station_id"})
-		bikesDisabledGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace: namespace,
-			Name:      "bikes_disabled",
-			Help:      "The number of disabled bikes",
-		}, []string{"station_id"})
-		docksAvailableGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace: namespace,
-			Name:      "docks_available",
-			Help:      "The number of docks accepting bike returns",
-		}, []string{"station_id"})
-		docksDisabledGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
-			Namespace: namespace,
-			Name:      "docks_disabled",
-			Help:      "The number of empty but disabled dock points",
-		}, []string{"station_id"})
-		installedGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	stationInfoGauge     *prometheus.GaugeVec
+	stationCapacityGauge *prometheus.GaugeVec
+
+	freeBikesGauge         *prometheus.GaugeVec
+	freeBikeRangeHistogram *prometheus.HistogramVec
+	vehicleTypeInfoGauge   *prometheus.GaugeVec
+
+	systemInfoGauge         *prometheus.GaugeVec
+	systemAlertsActiveGauge *prometheus.GaugeVec
+
+	cacheHitsCounter        *prometheus.CounterVec
+	cacheMissesCounter      *prometheus.CounterVec
+	upstreamRequestDuration *prometheus.HistogramVec
+
+	probeSuccessGauge    prometheus.Gauge
+	probeDurationSummary prometheus.Summary
+}
+
+// newGBFSMetrics builds and registers the metric vectors used by a probe.
+// constLabels (typically a system's static `labels:` from the config file)
+// are attached to every series the probe produces.
+func newGBFSMetrics(registry *prometheus.Registry, constLabels prometheus.Labels) *gbfsMetrics {
+	// feed is only carried on the station_status gauges, which predate the
+	// one-gauge-per-feed split below. Every other gauge here is produced by
+	// exactly one feed, and that feed is already encoded in the metric name
+	// itself (station_info <- station_information, free_bikes_total <-
+	// free_bike_status, and so on), so a feed label there would just be a
+	// redundant constant repeated on every series.
+	stationLabels := []string{"feed", "station_id"}
+	m := &gbfsMetrics{
+		bikesAvailableGauge: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "bikes_available",
+			Help:        "The number of bikes available for rental",
+			ConstLabels: constLabels,
+		}, stationLabels),
+		bikesDisabledGauge: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "bikes_disabled",
+			Help:        "The number of disabled bikes",
+			ConstLabels: constLabels,
+		}, stationLabels),
+		docksAvailableGauge: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "docks_available",
+			Help:        "The number of docks accepting bike returns",
+			ConstLabels: constLabels,
+		}, stationLabels),
+		docksDisabledGauge: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "docks_disabled",
+			Help:        "The number of empty but disabled dock points",
+			ConstLabels: constLabels,
+		}, stationLabels),
+		installedGauge: prometheus.NewGaugeVec(prometheus.GaugeOpts{
 			Namespace: namespace,
 			Name:      "installed",
 			Help: "Indicates if the station is currently renting bikes, " +
 				"regardless of if any bikes are available",
-		}, []string{"station_id"})
-		rentingGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			ConstLabels: constLabels,
+		}, stationLabels),
+		rentingGauge: prometheus.NewGaugeVec(prometheus.GaugeOpts{
 			Namespace: namespace,
 			Name:      "renting",
 			Help: "Indicates if the station is currently accepting bike returns, " +
 				"regardless of if any docks are available",
-		}, []string{"station_id"})
-		lastReportedGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			ConstLabels: constLabels,
+		}, stationLabels),
+		lastReportedGauge: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "last_reported_timestamp_seconds",
+			Help:        "Last time this station reported its status to the feed, in unixtime",
+			ConstLabels: constLabels,
+		}, stationLabels),
+		feedLastUpdatedGauge: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "feed_last_updated_timestamp_seconds",
+			Help:        "The last_updated timestamp reported by a GBFS feed, in unixtime",
+			ConstLabels: constLabels,
+		}, []string{"feed"}),
+		feedTTLGauge: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "feed_ttl_seconds",
+			Help:        "The ttl reported by a GBFS feed, in seconds",
+			ConstLabels: constLabels,
+		}, []string{"feed"}),
+		stationInfoGauge: prometheus.NewGaugeVec(prometheus.GaugeOpts{
 			Namespace: namespace,
-			Name:      "last_reported_timestamp_seconds",
-			Help:      "Last time this station reported its status to the feed, in unixtime",
-		}, []string{"station_id"})
-	)
+			Name:      "station_info",
+			Help: "Static station metadata, always 1. Use group_left on station_id " +
+				"to enrich the other gbfs_* station metrics without adding these as labels there.",
+			ConstLabels: constLabels,
+		}, []string{"station_id", "name", "region_id", "lat", "lon"}),
+		stationCapacityGauge: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "station_capacity",
+			Help:        "The total number of docks this station can hold, as reported by station_information",
+			ConstLabels: constLabels,
+		}, []string{"station_id"}),
+		freeBikesGauge: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "free_bikes_total",
+			Help:        "The number of dockless vehicles available for rental",
+			ConstLabels: constLabels,
+		}, []string{"vehicle_type_id", "is_reserved", "is_disabled"}),
+		freeBikeRangeHistogram: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Name:        "free_bike_range_meters",
+			Help:        "The remaining range of dockless vehicles, in meters, as reported by current_range_meters",
+			Buckets:     prometheus.ExponentialBuckets(100, 2, 10),
+			ConstLabels: constLabels,
+		}, []string{"vehicle_type_id"}),
+		vehicleTypeInfoGauge: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "vehicle_type_info",
+			Help:        "Static vehicle type metadata, always 1",
+			ConstLabels: constLabels,
+		}, []string{"vehicle_type_id", "form_factor", "propulsion_type", "max_range_meters"}),
+		systemInfoGauge: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "system_info",
+			Help:        "Static system metadata, always 1",
+			ConstLabels: constLabels,
+		}, []string{"system_id", "name", "timezone"}),
+		systemAlertsActiveGauge: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "system_alerts_active",
+			Help:        "The number of system_alerts entries currently in effect, by alert type",
+			ConstLabels: constLabels,
+		}, []string{"type"}),
+		cacheHitsCounter: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "probe_cache_hits_total",
+			Help:        "Number of upstream GBFS feed fetches served from the response cache",
+			ConstLabels: constLabels,
+		}, []string{"target"}),
+		cacheMissesCounter: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "probe_cache_misses_total",
+			Help:        "Number of upstream GBFS feed fetches that required a live request",
+			ConstLabels: constLabels,
+		}, []string{"target"}),
+		upstreamRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Name:        "probe_upstream_request_duration_seconds",
+			Help:        "Time spent fetching a feed from the upstream GBFS API",
+			Buckets:     prometheus.DefBuckets,
+			ConstLabels: constLabels,
+		}, []string{"target"}),
+		probeSuccessGauge: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Name:        "probe_success",
+			Help:        "Whether the probe of this system's GBFS feeds succeeded",
+			ConstLabels: constLabels,
+		}),
+		probeDurationSummary: prometheus.NewSummary(prometheus.SummaryOpts{
+			Namespace:   namespace,
+			Name:        "probe_duration_seconds",
+			Help:        "Total time taken to probe this system's GBFS feeds",
+			ConstLabels: constLabels,
+		}),
+	}
 
-	registry := prometheus.NewRegistry()
-	registry.MustRegister(bikesAvailableGauge)
-	registry.MustRegister(bikesDisabledGauge)
-	registry.MustRegister(docksAvailableGauge)
-	registry.MustRegister(docksDisabledGauge)
-	registry.MustRegister(installedGauge)
-	registry.MustRegister(rentingGauge)
-	registry.MustRegister(lastReportedGauge)
+	registry.MustRegister(m.bikesAvailableGauge)
+	registry.MustRegister(m.bikesDisabledGauge)
+	registry.MustRegister(m.docksAvailableGauge)
+	registry.MustRegister(m.docksDisabledGauge)
+	registry.MustRegister(m.installedGauge)
+	registry.MustRegister(m.rentingGauge)
+	registry.MustRegister(m.lastReportedGauge)
+	registry.MustRegister(m.feedLastUpdatedGauge)
+	registry.MustRegister(m.feedTTLGauge)
+	registry.MustRegister(m.stationInfoGauge)
+	registry.MustRegister(m.stationCapacityGauge)
+	registry.MustRegister(m.freeBikesGauge)
+	registry.MustRegister(m.freeBikeRangeHistogram)
+	registry.MustRegister(m.vehicleTypeInfoGauge)
+	registry.MustRegister(m.systemInfoGauge)
+	registry.MustRegister(m.systemAlertsActiveGauge)
+	registry.MustRegister(m.cacheHitsCounter)
+	registry.MustRegister(m.cacheMissesCounter)
+	registry.MustRegister(m.upstreamRequestDuration)
+	registry.MustRegister(m.probeSuccessGauge)
+	registry.MustRegister(m.probeDurationSummary)
+	return m
+}
+
+// recordFeedFreshness sets the feed_last_updated_timestamp_seconds and
+// feed_ttl_seconds gauges for feed from body's shared GBFSAPIResponse
+// fields.
+func (m *gbfsMetrics) recordFeedFreshness(feed string, body []byte) error {
+	resp := new(GBFSAPIResponse)
+	if err := json.Unmarshal(body, resp); err != nil {
+		return err
+	}
+	m.feedLastUpdatedGauge.With(prometheus.Labels{"feed": feed}).Set(float64(resp.LastUpdated))
+	m.feedTTLGauge.With(prometheus.Labels{"feed": feed}).Set(float64(resp.TTL))
+	return nil
+}
 
+// recordStationStatus populates the per-station gauges from a
+// station_status feed.
+func (m *gbfsMetrics) recordStationStatus(body []byte) error {
 	stationStatusResp, err := GetStationStatuses(body)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Could not unmarshal target JSON,"+
-			" target '%s' does not have the expected schema: %v", target, err), 400)
-		return
+		return err
 	}
 
 	for _, status := range stationStatusResp.Data.Stations {
-		bikesAvailableGauge.With(prometheus.Labels{"station_id": status.ID}).Set(float64(status.BikesAvailable))
-		bikesDisabledGauge.With(prometheus.Labels{"station_id": status.ID}).Set(float64(status.BikesDisabled))
-		docksAvailableGauge.With(prometheus.Labels{"station_id": status.ID}).Set(float64(status.DocksAvailable))
-		docksDisabledGauge.With(prometheus.Labels{"station_id": status.ID}).Set(float64(status.DocksDisabled))
-		installedGauge.With(prometheus.Labels{"station_id": status.ID}).Set(BoolToFloat64(status.Installed))
-		rentingGauge.With(prometheus.Labels{"station_id": status.ID}).Set(BoolToFloat64(status.Renting))
-		lastReportedGauge.With(prometheus.Labels{"station_id": status.ID}).Set(float64(status.LastReported))
+		labels := prometheus.Labels{"feed": feedStationStatus, "station_id": status.ID}
+		m.bikesAvailableGauge.With(labels).Set(float64(status.BikesAvailable))
+		m.bikesDisabledGauge.With(labels).Set(float64(status.BikesDisabled))
+		m.docksAvailableGauge.With(labels).Set(float64(status.DocksAvailable))
+		m.docksDisabledGauge.With(labels).Set(float64(status.DocksDisabled))
+		m.installedGauge.With(labels).Set(BoolToFloat64(status.Installed))
+		m.rentingGauge.With(labels).Set(BoolToFloat64(status.Renting))
+		m.lastReportedGauge.With(labels).Set(float64(status.LastReported))
 	}
+	return nil
+}
+
+// probeSystem fetches system's discovery document and every known feed it
+// points at, recording metrics as it goes. It reports whether the whole
+// probe succeeded; callers are expected to still serve whatever metrics
+// were gathered even on failure, per the gbfs_probe_success gauge.
+func probeSystem(ctx context.Context, system *SystemConfig, metrics *gbfsMetrics) bool {
+	body, err := fetchCachedURL(ctx, cache, metrics, system.DiscoveryURL, system.DiscoveryURL)
+	if err != nil {
+		scrapeErrorsTotal.WithLabelValues(stageOf(err)).Inc()
+		log.Printf("probe %s: HTTP error fetching discovery document: %v", system.Name, err)
+		return false
+	}
+
+	discovery, err := GetDiscoveryDocument(body)
+	if err != nil {
+		scrapeErrorsTotal.WithLabelValues(stageUnmarshal).Inc()
+		log.Printf("probe %s: target does not look like a GBFS discovery document: %v", system.Name, err)
+		return false
+	}
+
+	feeds, err := discovery.FeedsForLanguage(system.Language)
+	if err != nil {
+		scrapeErrorsTotal.WithLabelValues(stageSchema).Inc()
+		log.Printf("probe %s: could not resolve feeds: %v", system.Name, err)
+		return false
+	}
+
+	success := true
+	for _, feed := range feeds {
+		if !isKnownFeed(feed.Name) || !system.wantsFeed(feed.Name) {
+			continue
+		}
+
+		feedBody, err := fetchCachedURL(ctx, cache, metrics, system.DiscoveryURL, feed.URL)
+		if err != nil {
+			scrapeErrorsTotal.WithLabelValues(stageOf(err)).Inc()
+			log.Printf("probe %s: HTTP error fetching feed '%s': %v", system.Name, feed.Name, err)
+			success = false
+			continue
+		}
+
+		if err := metrics.recordFeedFreshness(feed.Name, feedBody); err != nil {
+			scrapeErrorsTotal.WithLabelValues(stageUnmarshal).Inc()
+			log.Printf("probe %s: could not unmarshal feed '%s': %v", system.Name, feed.Name, err)
+			success = false
+			continue
+		}
+
+		var recordErr error
+		switch feed.Name {
+		case feedStationStatus:
+			recordErr = metrics.recordStationStatus(feedBody)
+		case feedStationInformation:
+			recordErr = metrics.recordStationInformation(feedBody)
+		case feedFreeBikeStatus:
+			recordErr = metrics.recordFreeBikeStatus(feedBody)
+		case feedVehicleTypes:
+			recordErr = metrics.recordVehicleTypes(feedBody)
+		case feedSystemInformation:
+			recordErr = metrics.recordSystemInformation(feedBody)
+		case feedSystemAlerts:
+			recordErr = metrics.recordSystemAlerts(feedBody)
+		}
+		if recordErr != nil {
+			scrapeErrorsTotal.WithLabelValues(stageUnmarshal).Inc()
+			log.Printf("probe %s: could not unmarshal feed '%s': %v", system.Name, feed.Name, recordErr)
+			success = false
+		}
+	}
+	return success
+}
+
+func probeGBFS(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	moduleName := params.Get("module")
+	if moduleName == "" {
+		moduleName = params.Get("target")
+	}
+	if moduleName == "" {
+		http.Error(w, "module or target parameter missing", 400)
+		return
+	}
+
+	system, err := config.Lookup(moduleName)
+	if err != nil {
+		http.Error(w, err.Error(), 400)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), system.timeout())
+	defer cancel()
+
+	registry := prometheus.NewRegistry()
+	metrics := newGBFSMetrics(registry, system.Labels)
+
+	start := time.Now()
+	success := probeSystem(ctx, system, metrics)
+	metrics.probeDurationSummary.Observe(time.Since(start).Seconds())
+	metrics.probeSuccessGauge.Set(BoolToFloat64(success))
 
 	handler := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
 	handler.ServeHTTP(w, r)
 }
 
+// isKnownFeed reports whether name is a feed this exporter does anything
+// with.
+func isKnownFeed(name string) bool {
+	for _, known := range knownFeeds {
+		if name == known {
+			return true
+		}
+	}
+	return false
+}
+
 func main() {
+	flag.Parse()
+	cache = newResponseCache(*cacheMaxTTL)
+
+	var err error
+	config, err = LoadConfig(*configFile)
+	if err != nil {
+		log.Fatalln(errors.Wrapf(err, "Failed to load config file '%s'", *configFile))
+	}
+
 	log.Printf("G O O D B O I  L A U N C H I N G  ON  %s\n", listenAddress)
 
 	http.Handle("/metrics", promhttp.Handler())
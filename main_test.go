@@ -0,0 +1,100 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestFeedsForLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		language string
+		wantURL  string
+		wantErr  bool
+	}{
+		{
+			name:     "v3 flat feed list",
+			body:     `{"data":{"feeds":[{"name":"station_status","url":"http://example.com/station_status.json"}]}}`,
+			language: "",
+			wantURL:  "http://example.com/station_status.json",
+		},
+		{
+			name:     "v2 keyed by requested language",
+			body:     `{"data":{"en":{"feeds":[{"name":"station_status","url":"http://example.com/en/station_status.json"}]},"fr":{"feeds":[{"name":"station_status","url":"http://example.com/fr/station_status.json"}]}}}`,
+			language: "en",
+			wantURL:  "http://example.com/en/station_status.json",
+		},
+		{
+			name:     "v2 defaults to en when language unset",
+			body:     `{"data":{"fr":{"feeds":[{"name":"station_status","url":"http://example.com/fr/station_status.json"}]},"en":{"feeds":[{"name":"station_status","url":"http://example.com/en/station_status.json"}]}}}`,
+			language: "",
+			wantURL:  "http://example.com/en/station_status.json",
+		},
+		{
+			name:     "v2 missing requested language",
+			body:     `{"data":{"en":{"feeds":[]}}}`,
+			language: "fr",
+			wantErr:  true,
+		},
+		{
+			name:     "neither shape",
+			body:     `{"data":"not an object"}`,
+			language: "",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			discovery, err := GetDiscoveryDocument([]byte(tt.body))
+			if err != nil {
+				t.Fatalf("GetDiscoveryDocument() error = %v", err)
+			}
+
+			feeds, err := discovery.FeedsForLanguage(tt.language)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("FeedsForLanguage() error = nil, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FeedsForLanguage() error = %v", err)
+			}
+			if len(feeds) != 1 || feeds[0].URL != tt.wantURL {
+				t.Fatalf("FeedsForLanguage() = %+v, want single feed with url %q", feeds, tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestStationStatusUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want StationStatus
+	}{
+		{
+			name: "GBFS v3 booleans",
+			body: `{"station_id":"1","is_installed":true,"is_renting":false,"is_returning":true}`,
+			want: StationStatus{ID: "1", Installed: true, Renting: false, Returning: true},
+		},
+		{
+			name: "GBFS v2 0/1 integers",
+			body: `{"station_id":"1","is_installed":1,"is_renting":0,"is_returning":1}`,
+			want: StationStatus{ID: "1", Installed: true, Renting: false, Returning: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got StationStatus
+			if err := got.UnmarshalJSON([]byte(tt.body)); err != nil {
+				t.Fatalf("UnmarshalJSON() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("UnmarshalJSON() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
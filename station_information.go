@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// StationInformation holds the static metadata GBFS publishes about a
+// station, as opposed to its live status.
+type StationInformation struct {
+	ID       string  `json:"station_id"`
+	Name     string  `json:"name"`
+	RegionID string  `json:"region_id,omitempty"`
+	Capacity int64   `json:"capacity,omitempty"`
+	Lat      float64 `json:"lat"`
+	Lon      float64 `json:"lon"`
+}
+
+// StationInformationAPIResponse holds the API response for station
+// information.
+type StationInformationAPIResponse struct {
+	Data struct {
+		Stations []StationInformation `json:"stations"`
+	} `json:"data"`
+	GBFSAPIResponse
+}
+
+// GetStationInformation parses a station_information feed.
+func GetStationInformation(body []byte) (*StationInformationAPIResponse, error) {
+	resp := new(StationInformationAPIResponse)
+	err := json.Unmarshal(body, &resp)
+	return resp, err
+}
+
+// recordStationInformation populates the station_info info gauge from a
+// station_information feed, so it can be joined against the station_status
+// metrics in PromQL without bloating every gauge with rarely-changing
+// labels.
+func (m *gbfsMetrics) recordStationInformation(body []byte) error {
+	stationInfoResp, err := GetStationInformation(body)
+	if err != nil {
+		return err
+	}
+
+	for _, station := range stationInfoResp.Data.Stations {
+		labels := prometheus.Labels{
+			"station_id": station.ID,
+			"name":       station.Name,
+			"region_id":  station.RegionID,
+			"lat":        strconv.FormatFloat(station.Lat, 'f', -1, 64),
+			"lon":        strconv.FormatFloat(station.Lon, 'f', -1, 64),
+		}
+		m.stationInfoGauge.With(labels).Set(1)
+
+		if station.Capacity > 0 {
+			m.stationCapacityGauge.WithLabelValues(station.ID).Set(float64(station.Capacity))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// AlertTime is a single time window during which a SystemAlert is in
+// effect. An End of 0 means the alert has no known end time.
+type AlertTime struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end,omitempty"`
+}
+
+// SystemAlert is a single entry in a system_alerts feed, such as a planned
+// station closure or a system-wide outage.
+type SystemAlert struct {
+	ID    string      `json:"alert_id"`
+	Type  string      `json:"type"`
+	Times []AlertTime `json:"times,omitempty"`
+}
+
+// isActive reports whether the alert is in effect at t. An alert with no
+// times is treated as always active, per the GBFS spec's use of that case
+// to mean "in effect until further notice".
+func (a *SystemAlert) isActive(t int64) bool {
+	if len(a.Times) == 0 {
+		return true
+	}
+	for _, window := range a.Times {
+		if window.Start <= t && (window.End == 0 || t <= window.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// SystemAlertsAPIResponse holds the API response for system alerts.
+type SystemAlertsAPIResponse struct {
+	Data struct {
+		Alerts []SystemAlert `json:"alerts"`
+	} `json:"data"`
+	GBFSAPIResponse
+}
+
+// GetSystemAlerts parses a system_alerts feed.
+func GetSystemAlerts(body []byte) (*SystemAlertsAPIResponse, error) {
+	resp := new(SystemAlertsAPIResponse)
+	err := json.Unmarshal(body, &resp)
+	return resp, err
+}
+
+// recordSystemAlerts populates the system_alerts_active gauge, counting how
+// many alerts of each type are currently in effect.
+func (m *gbfsMetrics) recordSystemAlerts(body []byte) error {
+	alertsResp, err := GetSystemAlerts(body)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().Unix()
+	counts := make(map[string]int64)
+	for _, alert := range alertsResp.Data.Alerts {
+		if alert.isActive(now) {
+			counts[alert.Type]++
+		}
+	}
+
+	for alertType, count := range counts {
+		m.systemAlertsActiveGauge.With(prometheus.Labels{"type": alertType}).Set(float64(count))
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// SystemInformation holds the static metadata GBFS publishes about the
+// system as a whole, such as its name and timezone.
+type SystemInformation struct {
+	ID       string `json:"system_id"`
+	Name     string `json:"name"`
+	Timezone string `json:"timezone"`
+}
+
+// SystemInformationAPIResponse holds the API response for system
+// information.
+type SystemInformationAPIResponse struct {
+	Data SystemInformation `json:"data"`
+	GBFSAPIResponse
+}
+
+// GetSystemInformation parses a system_information feed.
+func GetSystemInformation(body []byte) (*SystemInformationAPIResponse, error) {
+	resp := new(SystemInformationAPIResponse)
+	err := json.Unmarshal(body, &resp)
+	return resp, err
+}
+
+// recordSystemInformation populates the system_info info gauge from a
+// system_information feed.
+func (m *gbfsMetrics) recordSystemInformation(body []byte) error {
+	systemInfoResp, err := GetSystemInformation(body)
+	if err != nil {
+		return err
+	}
+
+	labels := prometheus.Labels{
+		"system_id": systemInfoResp.Data.ID,
+		"name":      systemInfoResp.Data.Name,
+		"timezone":  systemInfoResp.Data.Timezone,
+	}
+	m.systemInfoGauge.With(labels).Set(1)
+	return nil
+}